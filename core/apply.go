@@ -0,0 +1,190 @@
+// Copyright 2025 Jelly Terra <jellyterra@proton.me>
+// This Source Code Form is subject to the terms of the Mozilla Public License, v. 2.0
+// that can be found in the LICENSE file and https://mozilla.org/MPL/2.0/.
+
+package core
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ApplyOptions controls how Apply executes a Plan.
+type ApplyOptions struct {
+	// Transactional snapshots every recordset a Change touches before
+	// applying anything, and restores those snapshots if any Change in
+	// the batch fails.
+	Transactional bool
+}
+
+// ChangeResult is the outcome of applying a single Change. Err is nil on
+// success.
+type ChangeResult struct {
+	Change *Change
+	Err    error
+}
+
+// Result is the aggregate outcome of an Apply call.
+type Result struct {
+	Changes []*ChangeResult
+
+	// RolledBack is set when Transactional mode successfully restored
+	// every affected recordset after a failure elsewhere in the batch. If
+	// the rollback itself only partially succeeded, RolledBack is left
+	// false and the returned error from Apply reports which recordsets
+	// restoreSnapshots could not restore.
+	RolledBack bool
+}
+
+// Err summarizes Changes into a single error, or nil if every change
+// succeeded.
+func (result *Result) Err() error {
+	var failed int
+	var first error
+
+	for _, cr := range result.Changes {
+		if cr.Err != nil {
+			failed++
+			if first == nil {
+				first = cr.Err
+			}
+		}
+	}
+
+	if failed == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%d of %d changes failed, first: %v", failed, len(result.Changes), first)
+}
+
+// Apply executes a computed Plan against the real registries, waiting for
+// every change to finish before returning. operations is the same slice
+// that produced changes; it is only used to resolve which registries to
+// build. In Transactional mode, a failure anywhere in the batch triggers a
+// rollback of every recordset the batch touched, and Result.RolledBack
+// reports whether that happened; the original Result.Err() still reflects
+// the failures that triggered it.
+func Apply(c *Context, operations []*Operation, changes []*Change, opts ApplyOptions) (*Result, error) {
+	registries, err := buildRegistries(c, operations)
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshots map[string][]Record
+	if opts.Transactional {
+		snapshots, err = snapshotAffected(registries, changes)
+		if err != nil {
+			return nil, fmt.Errorf("snapshotting for rollback: %v", err)
+		}
+	}
+
+	result := &Result{Changes: make([]*ChangeResult, len(changes))}
+
+	var wg sync.WaitGroup
+	for i, change := range changes {
+		wg.Add(1)
+		go func(i int, change *Change) {
+			defer wg.Done()
+			result.Changes[i] = &ChangeResult{Change: change, Err: applyChange(registries[registryKey(change.Tenant, change.Registry)], change)}
+		}(i, change)
+	}
+	wg.Wait()
+
+	if opts.Transactional && result.Err() != nil {
+		if err := restoreSnapshots(registries, snapshots); err != nil {
+			return result, fmt.Errorf("rollback after failed batch failed: %v", err)
+		}
+		result.RolledBack = true
+	}
+
+	return result, nil
+}
+
+func applyChange(registry Registry, change *Change) error {
+	switch change.Op {
+	case CHANGE_CREATE:
+		return registry.AppendRecord(&change.Record)
+	case CHANGE_MODIFY:
+		if err := registry.DeleteRecord(change.Previous); err != nil {
+			return err
+		}
+		return registry.AppendRecord(&change.Record)
+	case CHANGE_REMOVE:
+		return registry.DeleteRecord(&change.Record)
+	default:
+		return fmt.Errorf("unknown change op [%s]", change.Op)
+	}
+}
+
+// snapshotAffected records the current recordset for every distinct
+// (tenant, registry, canonical name) a Change touches, before any of them
+// apply.
+func snapshotAffected(registries map[string]Registry, changes []*Change) (map[string][]Record, error) {
+	snapshots := map[string][]Record{}
+
+	for _, change := range changes {
+		k := snapshotKey(change.Tenant, change.Registry, change.CanonicalName)
+		if _, ok := snapshots[k]; ok {
+			continue
+		}
+
+		records, err := registries[registryKey(change.Tenant, change.Registry)].ListRecordsWithDomain(change.CanonicalName)
+		if err != nil {
+			return nil, fmt.Errorf("listing records for [%s] on registry [%s]: %v", change.CanonicalName, change.Registry, err)
+		}
+
+		snapshots[k] = records
+	}
+
+	return snapshots, nil
+}
+
+// restoreSnapshots replaces every affected recordset with what
+// snapshotAffected captured, wiping whatever the failed batch left behind.
+// It keeps restoring every remaining entry even after one fails, so a
+// restore failure partway through doesn't leave an unknown mix of
+// restored and not-yet-restored recordsets; all failures are aggregated
+// into the returned error.
+func restoreSnapshots(registries map[string]Registry, snapshots map[string][]Record) error {
+	var failures []string
+
+	for k, records := range snapshots {
+		parts := strings.SplitN(k, "\x00", 3)
+		tenant, registryName, canonicalName := parts[0], parts[1], parts[2]
+
+		registry := registries[registryKey(tenant, registryName)]
+
+		if err := restoreRecordset(registry, canonicalName, records); err != nil {
+			failures = append(failures, fmt.Sprintf("[%s] on registry [%s]: %v", canonicalName, registryName, err))
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("%d of %d recordsets failed to restore: %s", len(failures), len(snapshots), strings.Join(failures, "; "))
+	}
+
+	return nil
+}
+
+// restoreRecordset wipes canonicalName on registry and re-appends records,
+// restoreSnapshots' per-entry step.
+func restoreRecordset(registry Registry, canonicalName string, records []Record) error {
+	if err := registry.DeleteAllRecordsWithDomain(canonicalName); err != nil {
+		return err
+	}
+
+	for _, r := range records {
+		r := r
+		if err := registry.AppendRecord(&r); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func snapshotKey(tenant, registry, canonicalName string) string {
+	return tenant + "\x00" + registry + "\x00" + canonicalName
+}
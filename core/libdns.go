@@ -0,0 +1,322 @@
+// Copyright 2025 Jelly Terra <jellyterra@proton.me>
+// This Source Code Form is subject to the terms of the Mozilla Public License, v. 2.0
+// that can be found in the LICENSE file and https://mozilla.org/MPL/2.0/.
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+// LibdnsProviderFactory builds the libdns provider client for one of the
+// ~40 backends implemented against github.com/libdns/libdns. It is handed
+// the same BuilderParams as the registry builder so that credentials can
+// be read straight through (e.g. params["token"], params["zone"]).
+type LibdnsProviderFactory func(params BuilderParams) (any, error)
+
+// LibdnsProviders holds the known libdns provider constructors, keyed by
+// provider name (e.g. "cloudflare", "route53", "hetzner", "desec"). Provider
+// packages register themselves here from an init func.
+var LibdnsProviders = map[string]LibdnsProviderFactory{}
+
+// RegisterLibdnsProvider makes a libdns provider constructor available to
+// the "libdns" registry builder under the given name.
+func RegisterLibdnsProvider(name string, factory LibdnsProviderFactory) {
+	LibdnsProviders[name] = factory
+}
+
+func init() {
+	RegistryBuilders["libdns"] = BuildLibdnsRegistry
+}
+
+// libdnsRegistry adapts a github.com/libdns/libdns provider client to the
+// Registry interface. The provider is expected to implement whichever of
+// libdns.RecordGetter, libdns.RecordAppender and libdns.RecordDeleter it
+// supports; most upstream providers implement all three.
+type libdnsRegistry struct {
+	provider string
+	zone     string
+
+	// pollInterval drives Watch's fallback polling, since libdns HTTP
+	// APIs have no push notifications of their own.
+	pollInterval time.Duration
+
+	getter   libdns.RecordGetter
+	appender libdns.RecordAppender
+	deleter  libdns.RecordDeleter
+}
+
+const defaultLibdnsPollInterval = 30 * time.Second
+
+// BuildLibdnsRegistry is the RegistryBuilder for the generic "libdns"
+// builder. BuilderParams must carry "provider" (the registered libdns
+// provider name) and "zone" (the zone libdns operates on, e.g.
+// "example.com."); any remaining params are passed through to the provider
+// factory untouched.
+func BuildLibdnsRegistry(params BuilderParams) (Registry, error) {
+	name, _ := params["provider"].(string)
+	if name == "" {
+		return nil, fmt.Errorf("libdns registry requires a \"provider\" param")
+	}
+
+	zone, _ := params["zone"].(string)
+	if zone == "" {
+		return nil, fmt.Errorf("libdns registry requires a \"zone\" param")
+	}
+
+	factory := LibdnsProviders[name]
+	if factory == nil {
+		return nil, fmt.Errorf("libdns provider [%s] is not registered", name)
+	}
+
+	client, err := factory(params)
+	if err != nil {
+		return nil, fmt.Errorf("libdns provider [%s] init failed: %v", name, err)
+	}
+
+	reg := &libdnsRegistry{provider: name, zone: zone, pollInterval: defaultLibdnsPollInterval}
+	if seconds, ok := params["poll_interval_seconds"].(int); ok && seconds > 0 {
+		reg.pollInterval = time.Duration(seconds) * time.Second
+	}
+
+	reg.getter, _ = client.(libdns.RecordGetter)
+	reg.appender, _ = client.(libdns.RecordAppender)
+	reg.deleter, _ = client.(libdns.RecordDeleter)
+
+	return reg, nil
+}
+
+// Watch polls ListRecordsWithDomain on reg.pollInterval and diffs each
+// poll against the last, since libdns providers have no push notification
+// API of their own.
+func (reg *libdnsRegistry) Watch(canonicalName string) (<-chan Event, func(), error) {
+	return PollWatch(reg, canonicalName, reg.pollInterval)
+}
+
+// relativeName returns canonicalName relative to the registry's zone, the
+// form libdns.Record.RR().Name expects (e.g. "www" for "www.example.com."
+// in zone "example.com.", "@" for the zone apex).
+func (reg *libdnsRegistry) relativeName(canonicalName string) string {
+	zone := strings.TrimSuffix(reg.zone, ".")
+	name := strings.TrimSuffix(canonicalName, ".")
+
+	if name == zone {
+		return "@"
+	}
+
+	return strings.TrimSuffix(strings.TrimSuffix(name, zone), ".")
+}
+
+// toLibdnsRecord translates a Record into the libdns.Record matching its
+// Type. CAA and SRV carry structured fields in Value, encoded the same way
+// autodns' config layer emits them: "flags tag value" for CAA and
+// "priority weight port target" for SRV.
+func (reg *libdnsRegistry) toLibdnsRecord(r *Record) (libdns.Record, error) {
+	name := reg.relativeName(r.CanonicalName)
+	ttl := time.Duration(r.TTL) * time.Second
+
+	switch strings.ToUpper(r.Type) {
+	case "A", "AAAA":
+		addr, err := netip.ParseAddr(r.Value)
+		if err != nil {
+			return nil, fmt.Errorf("parsing address [%s]: %v", r.Value, err)
+		}
+		return libdns.Address{Name: name, TTL: ttl, IP: addr}, nil
+
+	case "CNAME":
+		return libdns.CNAME{Name: name, TTL: ttl, Target: r.Value}, nil
+
+	case "TXT":
+		return libdns.TXT{Name: name, TTL: ttl, Text: r.Value}, nil
+
+	case "MX":
+		parts := strings.Fields(r.Value)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("MX value [%s] must be \"preference target\"", r.Value)
+		}
+		pref, err := strconv.ParseUint(parts[0], 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("parsing MX preference [%s]: %v", parts[0], err)
+		}
+		return libdns.MX{Name: name, TTL: ttl, Preference: uint16(pref), Target: parts[1]}, nil
+
+	case "SRV":
+		parts := strings.Fields(r.Value)
+		if len(parts) != 4 {
+			return nil, fmt.Errorf("SRV value [%s] must be \"priority weight port target\"", r.Value)
+		}
+		priority, err := strconv.ParseUint(parts[0], 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("parsing SRV priority [%s]: %v", parts[0], err)
+		}
+		weight, err := strconv.ParseUint(parts[1], 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("parsing SRV weight [%s]: %v", parts[1], err)
+		}
+		port, err := strconv.ParseUint(parts[2], 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("parsing SRV port [%s]: %v", parts[2], err)
+		}
+
+		labels := strings.SplitN(name, ".", 3)
+		if len(labels) < 2 || !strings.HasPrefix(labels[0], "_") || !strings.HasPrefix(labels[1], "_") {
+			return nil, fmt.Errorf("SRV record name [%s] must be \"_service._proto[.host]\"", name)
+		}
+		service := strings.TrimPrefix(labels[0], "_")
+		proto := strings.TrimPrefix(labels[1], "_")
+		host := "@"
+		if len(labels) == 3 {
+			host = labels[2]
+		}
+
+		return libdns.SRV{Service: service, Transport: proto, Name: host, TTL: ttl,
+			Priority: uint16(priority), Weight: uint16(weight), Port: uint16(port), Target: parts[3]}, nil
+
+	case "CAA":
+		parts := strings.SplitN(r.Value, " ", 3)
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("CAA value [%s] must be \"flags tag value\"", r.Value)
+		}
+		flags, err := strconv.ParseUint(parts[0], 10, 8)
+		if err != nil {
+			return nil, fmt.Errorf("parsing CAA flags [%s]: %v", parts[0], err)
+		}
+		return libdns.CAA{Name: name, TTL: ttl, Flags: uint8(flags), Tag: parts[1], Value: parts[2]}, nil
+
+	default:
+		return nil, fmt.Errorf("record type [%s] is not supported by the libdns adapter", r.Type)
+	}
+}
+
+func (reg *libdnsRegistry) AppendRecord(r *Record) error {
+	if reg.appender == nil {
+		return fmt.Errorf("libdns provider [%s] does not support appending records", reg.provider)
+	}
+
+	rec, err := reg.toLibdnsRecord(r)
+	if err != nil {
+		return err
+	}
+
+	_, err = reg.appender.AppendRecords(context.Background(), reg.zone, []libdns.Record{rec})
+	return err
+}
+
+func (reg *libdnsRegistry) DeleteRecord(r *Record) error {
+	if reg.deleter == nil {
+		return fmt.Errorf("libdns provider [%s] does not support deleting records", reg.provider)
+	}
+
+	rec, err := reg.toLibdnsRecord(r)
+	if err != nil {
+		return err
+	}
+
+	_, err = reg.deleter.DeleteRecords(context.Background(), reg.zone, []libdns.Record{rec})
+	return err
+}
+
+func (reg *libdnsRegistry) DeleteAllRecordsWithDomain(canonicalName string) error {
+	if reg.getter == nil || reg.deleter == nil {
+		return fmt.Errorf("libdns provider [%s] does not support listing and deleting records", reg.provider)
+	}
+
+	name := reg.relativeName(canonicalName)
+
+	all, err := reg.getter.GetRecords(context.Background(), reg.zone)
+	if err != nil {
+		return fmt.Errorf("listing records in zone [%s]: %v", reg.zone, err)
+	}
+
+	var toDelete []libdns.Record
+	for _, rec := range all {
+		if rec.RR().Name == name {
+			toDelete = append(toDelete, rec)
+		}
+	}
+	if len(toDelete) == 0 {
+		return nil
+	}
+
+	_, err = reg.deleter.DeleteRecords(context.Background(), reg.zone, toDelete)
+	return err
+}
+
+// ListRecordsWithDomain returns the records libdns currently reports for
+// canonicalName, translated back into Records. Types the adapter doesn't
+// know how to translate (anything outside A/AAAA/CNAME/TXT/MX/SRV/CAA) are
+// skipped rather than failing the whole listing.
+func (reg *libdnsRegistry) ListRecordsWithDomain(canonicalName string) ([]Record, error) {
+	if reg.getter == nil {
+		return nil, fmt.Errorf("libdns provider [%s] does not support listing records", reg.provider)
+	}
+
+	name := reg.relativeName(canonicalName)
+
+	all, err := reg.getter.GetRecords(context.Background(), reg.zone)
+	if err != nil {
+		return nil, fmt.Errorf("listing records in zone [%s]: %v", reg.zone, err)
+	}
+
+	var records []Record
+	for _, rec := range all {
+		rr := rec.RR()
+		if rr.Name != name {
+			continue
+		}
+
+		if r, ok := reg.fromLibdnsRecord(canonicalName, rr); ok {
+			records = append(records, r)
+		}
+	}
+
+	return records, nil
+}
+
+// fromLibdnsRecord is the inverse of toLibdnsRecord: it reduces a libdns
+// resource record back to the flat Type/Value/TTL shape Record uses. The
+// encodings for MX, SRV and CAA values mirror toLibdnsRecord exactly so a
+// round trip through Plan's diff produces matching keys.
+func (reg *libdnsRegistry) fromLibdnsRecord(canonicalName string, rr libdns.RR) (Record, bool) {
+	record := Record{CanonicalName: canonicalName, Type: rr.Type, TTL: int(rr.TTL.Seconds())}
+
+	switch rr.Type {
+	case "A", "AAAA", "CNAME", "TXT":
+		record.Value = rr.Data
+		return record, true
+
+	case "MX", "SRV":
+		// rr.Data already carries the provider's wire-format rdata, which
+		// matches the "field field ..." encoding toLibdnsRecord expects
+		// back for these types.
+		record.Value = rr.Data
+		return record, true
+
+	case "CAA":
+		// rr.Data is `fmt.Sprintf("%d %s %q", Flags, Tag, Value)` — the
+		// Value field is Go-quoted, unlike the "flags tag value" form
+		// toLibdnsRecord builds a libdns.CAA from. Unquote it so a
+		// listed record's Value matches what Plan's diff expects instead
+		// of permanently mismatching and getting recreated every run.
+		parts := strings.SplitN(rr.Data, " ", 3)
+		if len(parts) != 3 {
+			return Record{}, false
+		}
+		value, err := strconv.Unquote(parts[2])
+		if err != nil {
+			return Record{}, false
+		}
+		record.Value = parts[0] + " " + parts[1] + " " + value
+		return record, true
+
+	default:
+		return Record{}, false
+	}
+}
@@ -6,7 +6,6 @@ package core
 
 import (
 	"fmt"
-	"sync"
 
 	"golang.org/x/net/idna"
 )
@@ -23,9 +22,18 @@ type Operation struct {
 	Subdomain string `json:"subdomain"`
 
 	Registry string
+
+	// Tenant scopes this operation to one customer/team namespace. The
+	// empty tenant is the default namespace, still available to roles
+	// that predate tenant scoping (roleDef.Tenants is empty for those).
+	Tenant string `json:"tenant"`
 }
 
 func ValidateOperation(roleDef *RoleDef, op *Operation) error {
+	if !roleHasTenant(roleDef, op.Tenant) {
+		return fmt.Errorf("role is not scoped to tenant [%s]", op.Tenant)
+	}
+
 	result, err := Validate(roleDef, op.Domain, op.Subdomain)
 	if err != nil {
 		return err
@@ -52,92 +60,63 @@ func ValidateOperation(roleDef *RoleDef, op *Operation) error {
 	return nil
 }
 
-func ExecuteAll(c *Context, roleDef *RoleDef, operations []*Operation, callback func(err error, op *Operation)) error {
-
-	// Authorize and check.
-
-	for _, op := range operations {
-		err := ValidateOperation(roleDef, op)
-		if err != nil {
-			return err
-		}
+// roleHasTenant reports whether roleDef is bound to tenant. Roles with no
+// Tenants configured are unscoped and accept any tenant, so existing
+// single-tenant deployments keep working unmodified.
+func roleHasTenant(roleDef *RoleDef, tenant string) bool {
+	if len(roleDef.Tenants) == 0 {
+		return true
 	}
 
-	// Build registries.
-
-	registries := make(map[string]Registry)
-
-	for _, op := range operations {
-		registryDef, err := Query(c, &RegistryDef{}, "registry", op.Registry)
-		if err != nil {
-			return err
-		}
-
-		builder := RegistryBuilders[registryDef.Builder]
-		if builder == nil {
-			return fmt.Errorf("registry [%s] builder [%s] is not builtin", op.Registry, registryDef.Builder)
-		}
-
-		registries[op.Registry], err = builder(registryDef.BuilderParams)
-		if err != nil {
-			return fmt.Errorf("registry [%s] builder [%s] failed: %v", op.Registry, registryDef.Builder, err)
+	for _, t := range roleDef.Tenants {
+		if t == tenant {
+			return true
 		}
 	}
 
-	// Execute operations.
-
-	deleted := map[string][]*Operation{}
-	updated := map[string][]*Operation{}
+	return false
+}
 
-	for _, op := range operations {
-		switch op.Op {
-		case OP_DELETE:
-			deleted[op.Registry] = append(deleted[op.Registry], op)
-		case OP_UPDATE:
-			updated[op.Registry] = append(updated[op.Registry], op)
-		}
+// ExecuteAll validates operations, computes a diff-based Plan against the
+// current state of every referenced registry, and Applies it — touching
+// only the records that actually changed rather than wiping and
+// re-appending a whole domain. It is kept for existing callers; new code
+// that wants the per-change Result, or Transactional rollback, should call
+// Plan and Apply directly.
+//
+// callback is invoked once with a nil error for each OP_UPDATE operation
+// whose desired record already matched the registry's current state (no
+// Change was needed), and once per Change Apply produced otherwise —
+// including CHANGE_REMOVE entries for stale records that matched no
+// operation in operations at all, so the total number of invocations is
+// not bounded by len(operations).
+func ExecuteAll(c *Context, roleDef *RoleDef, operations []*Operation, callback func(err error, op *Operation)) error {
+	changes, noops, err := planDetailed(c, roleDef, operations)
+	if err != nil {
+		return err
 	}
 
-	hasBeenDeleted := map[string]bool{}
-
-	var wg sync.WaitGroup
-	for registryName, operations := range updated {
-		for _, op := range operations {
-
-			// Delete all records with same domain name.
-			if hasBeenDeleted[op.CanonicalName] {
-				continue
-			}
-			hasBeenDeleted[op.CanonicalName] = true
-
-			wg.Add(1)
-			go func() {
-				defer wg.Done()
-				err := registries[registryName].DeleteAllRecordsWithDomain(op.CanonicalName)
-				if err != nil {
-					callback(fmt.Errorf("deleting all records with domain [%s] failed: %v", op.Domain, err), op)
-				}
-			}()
-		}
+	result, err := Apply(c, operations, changes, ApplyOptions{})
+	if err != nil {
+		return err
 	}
-	wg.Wait()
-
-	for registryName, operations := range updated {
-		for _, op := range operations {
-			go func() {
-				err := registries[registryName].AppendRecord(&op.Record)
-				callback(err, op)
-			}()
-		}
+
+	for _, op := range noops {
+		callback(nil, op)
 	}
 
-	for registryName, operations := range deleted {
-		for _, op := range operations {
-			go func() {
-				err := registries[registryName].DeleteRecord(&op.Record)
-				callback(err, op)
-			}()
+	for _, cr := range result.Changes {
+		op := OP_UPDATE
+		if cr.Change.Op == CHANGE_REMOVE {
+			op = OP_DELETE
 		}
+
+		callback(cr.Err, &Operation{
+			Record:   cr.Change.Record,
+			Op:       op,
+			Registry: cr.Change.Registry,
+			Tenant:   cr.Change.Tenant,
+		})
 	}
 
 	return nil
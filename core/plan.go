@@ -0,0 +1,247 @@
+// Copyright 2025 Jelly Terra <jellyterra@proton.me>
+// This Source Code Form is subject to the terms of the Mozilla Public License, v. 2.0
+// that can be found in the LICENSE file and https://mozilla.org/MPL/2.0/.
+
+package core
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	CHANGE_CREATE = "create"
+	CHANGE_MODIFY = "modify"
+	CHANGE_REMOVE = "remove"
+)
+
+// Change is a single, minimal mutation computed by Plan to reconcile a
+// registry's recordset with the desired state of one
+// (Tenant, CanonicalName, Type) group. Previous is only set for
+// CHANGE_MODIFY, where it carries the existing record being replaced.
+type Change struct {
+	Registry      string
+	Tenant        string
+	CanonicalName string
+	Op            string
+	Record        Record
+	Previous      *Record
+}
+
+// recordGroup identifies the (registry, tenant, canonical name, record
+// type) a set of desired records is diffed within; records never move
+// between groups.
+type recordGroup struct {
+	registry string
+	tenant   string
+	name     string
+	typ      string
+}
+
+// registryKey is the local cache key buildRegistries builds each Registry
+// instance under, so Plan and Apply only build each one once per call.
+func registryKey(tenant, registry string) string {
+	return tenant + "\x00" + registry
+}
+
+// tenantQualifiedRegistryName is the name passed to Query when resolving a
+// RegistryDef, so each tenant can only ever resolve RegistryDefs stored
+// under its own namespace — two tenants naming the same registry (e.g.
+// "cloudflare-primary") resolve distinct RegistryDefs, not the same
+// credentials. The empty (default) tenant resolves registries by their
+// escaped bare name, which is the bare name itself unless it contains "/"
+// or "\", so configs that predate tenant scoping keep working unmodified
+// for such names. tenant and registry are escaped before joining, so a "/"
+// occurring inside either one can't make two distinct (tenant, registry)
+// pairs collide on the same qualified name.
+func tenantQualifiedRegistryName(tenant, registry string) string {
+	if tenant == "" {
+		return escapeNamespaceSegment(registry)
+	}
+	return escapeNamespaceSegment(tenant) + "/" + escapeNamespaceSegment(registry)
+}
+
+// escapeNamespaceSegment backslash-escapes "\" and "/" in s, so two
+// escaped segments joined by an unescaped "/" can always be told apart
+// unambiguously regardless of what either one contains.
+func escapeNamespaceSegment(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `/`, `\/`)
+	return s
+}
+
+// buildRegistries resolves and constructs the Registry for every distinct
+// (Tenant, Registry) pair referenced by operations, so Plan and Apply only
+// build each registry once per call.
+func buildRegistries(c *Context, operations []*Operation) (map[string]Registry, error) {
+	registries := make(map[string]Registry)
+
+	for _, op := range operations {
+		key := registryKey(op.Tenant, op.Registry)
+		if _, ok := registries[key]; ok {
+			continue
+		}
+
+		registryDef, err := Query(c, &RegistryDef{}, "registry", tenantQualifiedRegistryName(op.Tenant, op.Registry))
+		if err != nil {
+			return nil, err
+		}
+
+		builder := RegistryBuilders[registryDef.Builder]
+		if builder == nil {
+			return nil, fmt.Errorf("registry [%s] builder [%s] is not builtin", op.Registry, registryDef.Builder)
+		}
+
+		registries[key], err = builder(registryDef.BuilderParams)
+		if err != nil {
+			return nil, fmt.Errorf("registry [%s] builder [%s] failed: %v", op.Registry, registryDef.Builder, err)
+		}
+	}
+
+	return registries, nil
+}
+
+// requireSingleTenant rejects a batch that mixes operations from more than
+// one tenant; ExecuteAll and Plan only ever touch one tenant's registries
+// per call.
+func requireSingleTenant(operations []*Operation) error {
+	var tenant string
+	var seen bool
+
+	for _, op := range operations {
+		if !seen {
+			tenant, seen = op.Tenant, true
+			continue
+		}
+		if op.Tenant != tenant {
+			return fmt.Errorf("batch mixes tenants [%s] and [%s]; submit one tenant's operations per call", tenant, op.Tenant)
+		}
+	}
+
+	return nil
+}
+
+// Plan validates operations and computes the minimal ordered set of
+// changes needed to bring every referenced registry in line with the
+// desired state, without mutating anything. OP_UPDATE operations are
+// grouped by (Registry, Tenant, CanonicalName, Type) and diffed against
+// that group's current records, matched on value and TTL; OP_DELETE
+// operations map straight to a CHANGE_REMOVE. Records that already match
+// are left out of the plan entirely; see planDetailed if you need to know
+// which input operations those were.
+func Plan(c *Context, roleDef *RoleDef, operations []*Operation) ([]*Change, error) {
+	changes, _, err := planDetailed(c, roleDef, operations)
+	return changes, err
+}
+
+// planDetailed is Plan's implementation. It additionally returns the
+// OP_UPDATE operations whose desired record already matched current
+// state and so produced no Change, letting ExecuteAll still report them
+// to callers instead of silently dropping them.
+func planDetailed(c *Context, roleDef *RoleDef, operations []*Operation) ([]*Change, []*Operation, error) {
+	if err := requireSingleTenant(operations); err != nil {
+		return nil, nil, err
+	}
+
+	for _, op := range operations {
+		if err := ValidateOperation(roleDef, op); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	registries, err := buildRegistries(c, operations)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var changes []*Change
+	desired := map[recordGroup][]*Operation{}
+
+	for _, op := range operations {
+		switch op.Op {
+		case OP_UPDATE:
+			key := recordGroup{op.Registry, op.Tenant, op.CanonicalName, op.Type}
+			desired[key] = append(desired[key], op)
+		case OP_DELETE:
+			changes = append(changes, &Change{
+				Registry:      op.Registry,
+				Tenant:        op.Tenant,
+				CanonicalName: op.CanonicalName,
+				Op:            CHANGE_REMOVE,
+				Record:        op.Record,
+			})
+		}
+	}
+
+	var noops []*Operation
+
+	for key, ops := range desired {
+		current, err := registries[registryKey(key.tenant, key.registry)].ListRecordsWithDomain(key.name)
+		if err != nil {
+			return nil, nil, fmt.Errorf("listing records for [%s] on registry [%s]: %v", key.name, key.registry, err)
+		}
+
+		var currentOfType []Record
+		for _, r := range current {
+			if r.Type == key.typ {
+				currentOfType = append(currentOfType, r)
+			}
+		}
+
+		groupChanges, groupNoops := diffGroup(key.registry, key.tenant, key.name, ops, currentOfType)
+		changes = append(changes, groupChanges...)
+		noops = append(noops, groupNoops...)
+	}
+
+	return changes, noops, nil
+}
+
+// diffGroup compares the desired records for one group against its
+// current records, matched on value+ttl; duplicate desired records are
+// matched one-for-one against distinct current records rather than all
+// piling onto the same one, so a record submitted twice matches two
+// existing copies, and a duplicate left with no copy to match becomes a
+// create. A record present on only one side pairs up with an unmatched
+// record on the other side as a CHANGE_MODIFY, which Apply carries out as
+// a DeleteRecord of Previous followed by an AppendRecord; no registry call
+// updates a record in place. Anything left over becomes a plain CHANGE_CREATE or
+// CHANGE_REMOVE. Desired records that already matched a current one
+// produce no Change and are returned as noops instead.
+func diffGroup(registry, tenant, canonicalName string, ops []*Operation, current []Record) (changes []*Change, noops []*Operation) {
+	key := func(r Record) string { return fmt.Sprintf("%s\x00%d", r.Value, r.TTL) }
+
+	remaining := map[string][]Record{}
+	for _, r := range current {
+		remaining[key(r)] = append(remaining[key(r)], r)
+	}
+
+	var creates []Record
+	for _, op := range ops {
+		k := key(op.Record)
+		if rs := remaining[k]; len(rs) > 0 {
+			remaining[k] = rs[1:]
+			noops = append(noops, op)
+			continue
+		}
+		creates = append(creates, op.Record)
+	}
+
+	var removes []Record
+	for _, rs := range remaining {
+		removes = append(removes, rs...)
+	}
+
+	for len(creates) > 0 && len(removes) > 0 {
+		next, prev := creates[0], removes[0]
+		creates, removes = creates[1:], removes[1:]
+		changes = append(changes, &Change{Registry: registry, Tenant: tenant, CanonicalName: canonicalName, Op: CHANGE_MODIFY, Record: next, Previous: &prev})
+	}
+	for _, r := range creates {
+		changes = append(changes, &Change{Registry: registry, Tenant: tenant, CanonicalName: canonicalName, Op: CHANGE_CREATE, Record: r})
+	}
+	for _, r := range removes {
+		changes = append(changes, &Change{Registry: registry, Tenant: tenant, CanonicalName: canonicalName, Op: CHANGE_REMOVE, Record: r})
+	}
+
+	return changes, noops
+}
@@ -0,0 +1,58 @@
+// Copyright 2025 Jelly Terra <jellyterra@proton.me>
+// This Source Code Form is subject to the terms of the Mozilla Public License, v. 2.0
+// that can be found in the LICENSE file and https://mozilla.org/MPL/2.0/.
+
+package core
+
+import "testing"
+
+func TestTenantQualifiedRegistryNameNoCollision(t *testing.T) {
+	a := tenantQualifiedRegistryName("acme/eu", "primary")
+	b := tenantQualifiedRegistryName("acme", "eu/primary")
+
+	if a == b {
+		t.Fatalf("tenantQualifiedRegistryName(%q, %q) and tenantQualifiedRegistryName(%q, %q) collide: both %q", "acme/eu", "primary", "acme", "eu/primary", a)
+	}
+}
+
+func TestTenantQualifiedRegistryNameDefaultTenant(t *testing.T) {
+	if got, want := tenantQualifiedRegistryName("", "primary"), "primary"; got != want {
+		t.Errorf("tenantQualifiedRegistryName(\"\", %q) = %q, want %q", "primary", got, want)
+	}
+}
+
+func TestDiffGroupUnmatchedDuplicateDesiredRecordIsACreate(t *testing.T) {
+	record := Record{Value: "1.2.3.4", TTL: 300}
+	ops := []*Operation{
+		{Record: record, Op: OP_UPDATE},
+		{Record: record, Op: OP_UPDATE},
+	}
+	current := []Record{record}
+
+	changes, noops := diffGroup("registry", "", "www.example.com", ops, current)
+
+	if len(changes) != 1 || changes[0].Op != CHANGE_CREATE {
+		t.Fatalf("got %d changes (%+v), want exactly 1 CHANGE_CREATE for the unmatched duplicate", len(changes), changes)
+	}
+	if len(noops) != 1 {
+		t.Fatalf("got %d noops, want exactly 1 for the record that already matched", len(noops))
+	}
+}
+
+func TestDiffGroupMatchesEachDesiredRecordOnce(t *testing.T) {
+	record := Record{Value: "1.2.3.4", TTL: 300}
+	ops := []*Operation{
+		{Record: record, Op: OP_UPDATE},
+		{Record: record, Op: OP_UPDATE},
+	}
+	current := []Record{record, record}
+
+	changes, noops := diffGroup("registry", "", "www.example.com", ops, current)
+
+	if len(changes) != 0 {
+		t.Fatalf("got %d changes, want 0 when both desired records already exist", len(changes))
+	}
+	if len(noops) != 2 {
+		t.Fatalf("got %d noops, want 2", len(noops))
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2025 Jelly Terra <jellyterra@proton.me>
+// This Source Code Form is subject to the terms of the Mozilla Public License, v. 2.0
+// that can be found in the LICENSE file and https://mozilla.org/MPL/2.0/.
+
+package core
+
+// Report is the structured result of a dry run: for every registry
+// touched by a batch of operations, the changes Apply would have made,
+// grouped by kind, plus any non-fatal warnings noticed while planning.
+type Report struct {
+	Registries map[string]*RegistryReport
+	Warnings   []string
+}
+
+// RegistryReport groups one registry's planned changes by kind. Each
+// Change still carries its own CanonicalName and Record.
+type RegistryReport struct {
+	Creates  []*Change
+	Modifies []*Change
+	Removes  []*Change
+}
+
+// Preview computes the same diff-based Plan ExecuteAll would apply, but
+// performs no mutating registry calls — the equivalent of `dnscontrol
+// preview`. Use it in CI to review the intended effect of a batch of
+// operations before running ExecuteAll against production zones.
+func Preview(c *Context, roleDef *RoleDef, operations []*Operation) (*Report, error) {
+	changes, err := Plan(c, roleDef, operations)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Registries: map[string]*RegistryReport{}}
+
+	for _, change := range changes {
+		reg := report.Registries[change.Registry]
+		if reg == nil {
+			reg = &RegistryReport{}
+			report.Registries[change.Registry] = reg
+		}
+
+		switch change.Op {
+		case CHANGE_CREATE:
+			reg.Creates = append(reg.Creates, change)
+		case CHANGE_MODIFY:
+			reg.Modifies = append(reg.Modifies, change)
+		case CHANGE_REMOVE:
+			reg.Removes = append(reg.Removes, change)
+		}
+	}
+
+	return report, nil
+}
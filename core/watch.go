@@ -0,0 +1,173 @@
+// Copyright 2025 Jelly Terra <jellyterra@proton.me>
+// This Source Code Form is subject to the terms of the Mozilla Public License, v. 2.0
+// that can be found in the LICENSE file and https://mozilla.org/MPL/2.0/.
+
+package core
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	EVENT_CREATE = "create"
+	EVENT_UPDATE = "update"
+	EVENT_REMOVE = "remove"
+)
+
+// Event is a single record change observed through Watch. Registry is
+// filled in by Watch itself; a Registry.Watch implementation only needs
+// to set CanonicalName, Op and Record.
+type Event struct {
+	Registry      string
+	CanonicalName string
+	Op            string
+	Record        Record
+}
+
+// Watch subscribes to record changes on canonicalName across every
+// registry in registries, merging their individual Registry.Watch
+// channels into one. The returned stop func unsubscribes from all of
+// them; callers should always call it once done. If any registry's Watch
+// fails, Watch stops the watches it had already started itself and
+// returns a nil stop func along with the error. stop also releases
+// any forwarding goroutine currently blocked trying to send on events, so
+// a caller that stops draining events before calling stop doesn't leak
+// the goroutine and its registry channel forever.
+func Watch(registries map[string]Registry, canonicalName string) (<-chan Event, func(), error) {
+	events := make(chan Event)
+	done := make(chan struct{})
+	var stops []func()
+
+	for name, registry := range registries {
+		ch, stop, err := registry.Watch(canonicalName)
+		if err != nil {
+			for _, s := range stops {
+				s()
+			}
+			return nil, nil, fmt.Errorf("watching [%s] on registry [%s]: %v", canonicalName, name, err)
+		}
+
+		stops = append(stops, stop)
+
+		go func(name string, ch <-chan Event) {
+			for e := range ch {
+				e.Registry = name
+				select {
+				case events <- e:
+				case <-done:
+					return
+				}
+			}
+		}(name, ch)
+	}
+
+	return events, func() {
+		close(done)
+		for _, s := range stops {
+			s()
+		}
+	}, nil
+}
+
+// PollWatch is the Registry.Watch fallback for backends without native
+// change notifications (most HTTP APIs): it polls
+// Registry.ListRecordsWithDomain every interval and diffs against the
+// previous poll, the same value+ttl matching Plan's diffGroup uses.
+func PollWatch(registry Registry, canonicalName string, interval time.Duration) (<-chan Event, func(), error) {
+	events := make(chan Event)
+	stop := make(chan struct{})
+
+	go func() {
+		defer close(events)
+
+		var previous []Record
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			current, err := registry.ListRecordsWithDomain(canonicalName)
+			if err == nil {
+				for _, e := range diffToEvents(canonicalName, previous, current) {
+					select {
+					case events <- e:
+					case <-stop:
+						return
+					}
+				}
+				previous = current
+			}
+
+			select {
+			case <-ticker.C:
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	return events, func() { close(stop) }, nil
+}
+
+// diffToEvents compares two snapshots of the same canonical name's
+// records, grouped by type and matched on value+ttl within each type: a
+// record unmatched on both sides of the same type pairs up as an
+// EVENT_UPDATE, and anything left over is a plain EVENT_CREATE or
+// EVENT_REMOVE.
+func diffToEvents(canonicalName string, previous, current []Record) []Event {
+	key := func(r Record) string { return fmt.Sprintf("%s\x00%d", r.Value, r.TTL) }
+
+	previousByType := map[string]map[string]Record{}
+	for _, r := range previous {
+		if previousByType[r.Type] == nil {
+			previousByType[r.Type] = map[string]Record{}
+		}
+		previousByType[r.Type][key(r)] = r
+	}
+
+	currentByType := map[string][]Record{}
+	for _, r := range current {
+		currentByType[r.Type] = append(currentByType[r.Type], r)
+	}
+
+	var events []Event
+
+	for typ, records := range currentByType {
+		remaining := previousByType[typ]
+		delete(previousByType, typ)
+
+		var creates []Record
+		for _, r := range records {
+			if _, ok := remaining[key(r)]; ok {
+				delete(remaining, key(r))
+				continue
+			}
+			creates = append(creates, r)
+		}
+
+		var removes []Record
+		for _, r := range remaining {
+			removes = append(removes, r)
+		}
+
+		for len(creates) > 0 && len(removes) > 0 {
+			next := creates[0]
+			creates, removes = creates[1:], removes[1:]
+			events = append(events, Event{CanonicalName: canonicalName, Op: EVENT_UPDATE, Record: next})
+		}
+		for _, r := range creates {
+			events = append(events, Event{CanonicalName: canonicalName, Op: EVENT_CREATE, Record: r})
+		}
+		for _, r := range removes {
+			events = append(events, Event{CanonicalName: canonicalName, Op: EVENT_REMOVE, Record: r})
+		}
+	}
+
+	for _, remaining := range previousByType {
+		for _, r := range remaining {
+			events = append(events, Event{CanonicalName: canonicalName, Op: EVENT_REMOVE, Record: r})
+		}
+	}
+
+	return events
+}
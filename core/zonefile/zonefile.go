@@ -0,0 +1,289 @@
+// Copyright 2025 Jelly Terra <jellyterra@proton.me>
+// This Source Code Form is subject to the terms of the Mozilla Public License, v. 2.0
+// that can be found in the LICENSE file and https://mozilla.org/MPL/2.0/.
+
+// Package zonefile converts between RFC 1035 BIND zone files and the
+// core.Record / core.Operation types, so a whole zone can be bulk-synced
+// with `autodns apply zone.txt` or exported for backup and version
+// control.
+package zonefile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/xentrasolv/autodns/core"
+)
+
+// Parse reads a zone file from r and returns one OP_UPDATE Operation per
+// resource record line, honoring $ORIGIN and $TTL directives and
+// resolving relative names against the current origin. Each record line
+// must carry an explicit owner name; the "blank name repeats the previous
+// owner" BIND shorthand is not supported.
+func Parse(r io.Reader) ([]*core.Operation, error) {
+	scanner := bufio.NewScanner(r)
+
+	var origin string
+	defaultTTL := 3600
+
+	var operations []*core.Operation
+
+	for scanner.Scan() {
+		text := scanner.Text()
+
+		fields, err := tokenize(text)
+		if err != nil {
+			return nil, fmt.Errorf("tokenizing %q: %v", text, err)
+		}
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch {
+		case strings.EqualFold(fields[0], "$ORIGIN"):
+			if len(fields) != 2 {
+				return nil, fmt.Errorf("malformed $ORIGIN line: %q", text)
+			}
+			origin = fields[1]
+			continue
+
+		case strings.EqualFold(fields[0], "$TTL"):
+			if len(fields) != 2 {
+				return nil, fmt.Errorf("malformed $TTL line: %q", text)
+			}
+			ttl, err := strconv.Atoi(fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("parsing $TTL %q: %v", fields[1], err)
+			}
+			defaultTTL = ttl
+			continue
+		}
+
+		if origin == "" {
+			return nil, fmt.Errorf("record %q appears before $ORIGIN", text)
+		}
+
+		op, err := parseRecordLine(fields, origin, defaultTTL)
+		if err != nil {
+			return nil, fmt.Errorf("parsing %q: %v", text, err)
+		}
+
+		operations = append(operations, op)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return operations, nil
+}
+
+// tokenize splits a zone-file line into whitespace-separated fields,
+// honoring double-quoted strings: whitespace and ';' inside a quoted
+// string are literal rather than a field separator or a comment, '\"'
+// and '\\' are unescaped to a literal '"' and '\\', and the surrounding
+// quotes are stripped from the resulting field. This keeps an ordinary
+// quoted TXT value like "v=DKIM1; k=rsa; p=MIGf..." from being
+// truncated at the first semicolon, and round-trips whatever quoteField
+// escapes on export. Everything from an unquoted ';' to the end of the
+// line is a comment and is dropped.
+func tokenize(line string) ([]string, error) {
+	var fields []string
+	var field strings.Builder
+	var inField, inQuotes bool
+
+	flush := func() {
+		if inField {
+			fields = append(fields, field.String())
+			field.Reset()
+			inField = false
+		}
+	}
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+
+		switch {
+		case inQuotes && c == '\\' && i+1 < len(line) && (line[i+1] == '"' || line[i+1] == '\\'):
+			field.WriteByte(line[i+1])
+			i++
+
+		case inQuotes:
+			if c == '"' {
+				inQuotes = false
+				continue
+			}
+			field.WriteByte(c)
+
+		case c == '"':
+			inQuotes = true
+			inField = true
+
+		case c == ';':
+			flush()
+			return fields, nil
+
+		case c == ' ' || c == '\t':
+			flush()
+
+		default:
+			inField = true
+			field.WriteByte(c)
+		}
+	}
+
+	if inQuotes {
+		return nil, fmt.Errorf("unterminated quoted string")
+	}
+
+	flush()
+
+	return fields, nil
+}
+
+// parseRecordLine parses "name [ttl] [class] type rdata..." into an
+// Operation. class, when present, must be IN; anything else is rejected
+// rather than silently ignored.
+func parseRecordLine(fields []string, origin string, defaultTTL int) (*core.Operation, error) {
+	if len(fields) < 3 {
+		return nil, fmt.Errorf("expected a name, type and value")
+	}
+
+	name := fields[0]
+	fields = fields[1:]
+	ttl := defaultTTL
+
+	if n, err := strconv.Atoi(fields[0]); err == nil {
+		ttl = n
+		fields = fields[1:]
+	}
+
+	if len(fields) > 0 {
+		switch strings.ToUpper(fields[0]) {
+		case "IN":
+			fields = fields[1:]
+		case "CH", "HS", "CS":
+			return nil, fmt.Errorf("class %q is not supported, only IN", fields[0])
+		}
+	}
+
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("expected a record type and value")
+	}
+
+	canonical := resolveName(name, origin)
+	domain, subdomain := splitCanonical(canonical, origin)
+
+	return &core.Operation{
+		Record: core.Record{
+			CanonicalName: canonical,
+			Type:          strings.ToUpper(fields[0]),
+			Value:         strings.Join(fields[1:], " "),
+			TTL:           ttl,
+		},
+		Op:        core.OP_UPDATE,
+		Domain:    domain,
+		Subdomain: subdomain,
+	}, nil
+}
+
+// resolveName expands a zone-file owner name against origin: "@" and the
+// empty name mean the zone apex, a trailing dot means already-absolute,
+// anything else is relative to origin.
+func resolveName(name, origin string) string {
+	switch {
+	case name == "@" || name == "":
+		return origin
+	case strings.HasSuffix(name, "."):
+		return strings.TrimSuffix(name, ".")
+	default:
+		return name + "." + strings.TrimSuffix(origin, ".")
+	}
+}
+
+// splitCanonical reduces an absolute name back to the Domain/Subdomain
+// pair ValidateOperation expects, treating origin as the domain.
+func splitCanonical(canonical, origin string) (domain, subdomain string) {
+	domain = strings.TrimSuffix(origin, ".")
+	canonical = strings.TrimSuffix(canonical, ".")
+
+	if canonical == domain {
+		return domain, ""
+	}
+
+	return domain, strings.TrimSuffix(canonical, "."+domain)
+}
+
+// Export renders the current records of canonicalNames on registry as a
+// zone file under origin, querying Registry.ListRecordsWithDomain for
+// each name.
+func Export(w io.Writer, registry core.Registry, origin string, canonicalNames []string) error {
+	if _, err := fmt.Fprintf(w, "$ORIGIN %s.\n", strings.TrimSuffix(origin, ".")); err != nil {
+		return err
+	}
+
+	for _, name := range canonicalNames {
+		records, err := registry.ListRecordsWithDomain(name)
+		if err != nil {
+			return fmt.Errorf("listing records for [%s]: %v", name, err)
+		}
+
+		relative := relativeName(name, origin)
+
+		for _, r := range records {
+			if _, err := fmt.Fprintf(w, "%s\t%d\tIN\t%s\t%s\n", relative, r.TTL, r.Type, quoteField(r.Value)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// quoteField renders value as a single zone-file field, quoting and
+// escaping it if it contains whitespace, ';', '"', '\\', or a control
+// character; whitespace, ';' and '"' would otherwise split the field,
+// start a comment or open a quoted string when tokenize reads it back.
+// This is what lets a TXT/CAA value like a DKIM key
+// ("v=DKIM1; k=rsa; p=...") round-trip through Export then Parse intact
+// instead of being truncated at the first unquoted ';'.
+func quoteField(value string) string {
+	needsQuoting := false
+	for _, r := range value {
+		if r == ' ' || r == '\t' || r == ';' || r == '"' || r == '\\' || r < 0x20 {
+			needsQuoting = true
+			break
+		}
+	}
+	if !needsQuoting {
+		return value
+	}
+
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range value {
+		if r == '"' || r == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	b.WriteByte('"')
+
+	return b.String()
+}
+
+// relativeName is resolveName's inverse: it reduces an absolute name to
+// its zone-file owner form, "@" for the apex.
+func relativeName(canonical, origin string) string {
+	origin = strings.TrimSuffix(origin, ".")
+	canonical = strings.TrimSuffix(canonical, ".")
+
+	if canonical == origin {
+		return "@"
+	}
+
+	return strings.TrimSuffix(canonical, "."+origin)
+}
@@ -0,0 +1,123 @@
+// Copyright 2025 Jelly Terra <jellyterra@proton.me>
+// This Source Code Form is subject to the terms of the Mozilla Public License, v. 2.0
+// that can be found in the LICENSE file and https://mozilla.org/MPL/2.0/.
+
+package zonefile
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseQuotedTXTWithSemicolon(t *testing.T) {
+	zone := `$ORIGIN example.com.
+$TTL 3600
+host IN TXT "v=DKIM1; k=rsa; p=MIGfMA0"
+`
+	operations, err := Parse(strings.NewReader(zone))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(operations) != 1 {
+		t.Fatalf("got %d operations, want 1", len(operations))
+	}
+
+	got := operations[0].Value
+	want := "v=DKIM1; k=rsa; p=MIGfMA0"
+	if got != want {
+		t.Errorf("Value = %q, want %q", got, want)
+	}
+}
+
+func TestParseCommentAfterRecord(t *testing.T) {
+	zone := `$ORIGIN example.com.
+www IN A 203.0.113.1 ; primary
+`
+	operations, err := Parse(strings.NewReader(zone))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(operations) != 1 {
+		t.Fatalf("got %d operations, want 1", len(operations))
+	}
+
+	if got, want := operations[0].Value, "203.0.113.1"; got != want {
+		t.Errorf("Value = %q, want %q", got, want)
+	}
+}
+
+func TestParseUnterminatedQuote(t *testing.T) {
+	zone := `$ORIGIN example.com.
+host IN TXT "unterminated
+`
+	if _, err := Parse(strings.NewReader(zone)); err == nil {
+		t.Fatal("expected an error for an unterminated quoted string, got nil")
+	}
+}
+
+func TestResolveName(t *testing.T) {
+	cases := []struct {
+		name, origin, want string
+	}{
+		{"@", "example.com.", "example.com."},
+		{"", "example.com.", "example.com."},
+		{"www", "example.com.", "www.example.com"},
+		{"www.example.com.", "example.com.", "www.example.com"},
+	}
+
+	for _, c := range cases {
+		if got := resolveName(c.name, c.origin); got != c.want {
+			t.Errorf("resolveName(%q, %q) = %q, want %q", c.name, c.origin, got, c.want)
+		}
+	}
+}
+
+func TestSplitCanonical(t *testing.T) {
+	domain, subdomain := splitCanonical("www.example.com", "example.com.")
+	if domain != "example.com" || subdomain != "www" {
+		t.Errorf("got domain=%q subdomain=%q, want domain=%q subdomain=%q", domain, subdomain, "example.com", "www")
+	}
+
+	domain, subdomain = splitCanonical("example.com", "example.com.")
+	if domain != "example.com" || subdomain != "" {
+		t.Errorf("got domain=%q subdomain=%q, want domain=%q subdomain=%q", domain, subdomain, "example.com", "")
+	}
+}
+
+func TestRelativeName(t *testing.T) {
+	if got, want := relativeName("example.com", "example.com."), "@"; got != want {
+		t.Errorf("relativeName = %q, want %q", got, want)
+	}
+	if got, want := relativeName("www.example.com", "example.com."), "www"; got != want {
+		t.Errorf("relativeName = %q, want %q", got, want)
+	}
+}
+
+func TestQuoteFieldRoundTripsThroughTokenize(t *testing.T) {
+	values := []string{
+		"v=DKIM1; k=rsa; p=MIGfMA0",
+		`has "quotes" and \backslash`,
+		"203.0.113.1",
+		"plain-value",
+	}
+
+	for _, value := range values {
+		line := "host IN TXT " + quoteField(value)
+		fields, err := tokenize(line)
+		if err != nil {
+			t.Fatalf("tokenize(%q): %v", line, err)
+		}
+		if len(fields) != 4 {
+			t.Fatalf("tokenize(%q) = %v, want 4 fields", line, fields)
+		}
+		if got := fields[3]; got != value {
+			t.Errorf("round trip of %q through quoteField/tokenize = %q", value, got)
+		}
+	}
+}
+
+func TestQuoteFieldLeavesPlainValuesBare(t *testing.T) {
+	if got, want := quoteField("203.0.113.1"), "203.0.113.1"; got != want {
+		t.Errorf("quoteField(%q) = %q, want unquoted %q", "203.0.113.1", got, want)
+	}
+}